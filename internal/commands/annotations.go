@@ -0,0 +1,169 @@
+package commands
+
+import "strings"
+
+// PolicyAnnotations holds the structured set of @-prefixed tags parsed from a
+// policy's comment block, e.g. @title, @severity or @parameters. Lines above
+// the first recognized tag are folded into Description as free-form text,
+// unless an @description tag sets Description, in which case they are dropped.
+type PolicyAnnotations struct {
+	Title              string
+	Description        string
+	Severity           string
+	Enforcement        string
+	Parameters         []PolicyParameter
+	MatchLabels        []string
+	ExcludedNamespaces []string
+	See                []string
+	Examples           []string
+}
+
+// PolicyParameter is a single entry from an @parameters annotation, e.g.
+// `@parameters limit:int=10`.
+type PolicyParameter struct {
+	Name    string
+	Type    string
+	Default string
+}
+
+// annotationTags lists the @-prefixed tags recognized in a policy comment
+// block, other than @Kinds which keeps its own dedicated parsing.
+var annotationTags = []string{
+	"@title",
+	"@description",
+	"@severity",
+	"@enforcement",
+	"@parameters",
+	"@matchLabels",
+	"@excludedNamespaces",
+	"@see",
+	"@example",
+}
+
+// parseAnnotations walks a block of consecutive comment lines, splitting them
+// by @-prefixed tag and folding continuation lines into the previous tag's
+// value, similar to how go/ast groups a comment block with the declaration it
+// documents. Lines above the first tag become the block's description when
+// no @description tag is present.
+func parseAnnotations(lines []string) PolicyAnnotations {
+	var annotations PolicyAnnotations
+	var currentTag string
+	var description []string
+
+	for _, line := range lines {
+		trimmed := strings.TrimSpace(line)
+
+		tag, value, ok := splitTag(trimmed)
+		if !ok {
+			if currentTag == "" {
+				if trimmed != "" {
+					description = append(description, trimmed)
+				}
+				continue
+			}
+
+			appendContinuation(&annotations, currentTag, trimmed)
+			continue
+		}
+
+		currentTag = tag
+		applyTag(&annotations, tag, value)
+	}
+
+	if annotations.Description == "" {
+		annotations.Description = strings.TrimSpace(strings.Join(description, " "))
+	}
+
+	return annotations
+}
+
+// splitTag reports whether line starts a known annotation tag, returning the
+// tag and the remainder of the line as its value.
+func splitTag(line string) (tag, value string, ok bool) {
+	for _, candidate := range annotationTags {
+		if line == candidate {
+			return candidate, "", true
+		}
+		if strings.HasPrefix(line, candidate+" ") {
+			return candidate, strings.TrimSpace(strings.TrimPrefix(line, candidate)), true
+		}
+	}
+
+	return "", "", false
+}
+
+func applyTag(annotations *PolicyAnnotations, tag, value string) {
+	switch tag {
+	case "@title":
+		annotations.Title = value
+	case "@description":
+		annotations.Description = value
+	case "@severity":
+		annotations.Severity = value
+	case "@enforcement":
+		annotations.Enforcement = value
+	case "@parameters":
+		if parameter, ok := parseParameter(value); ok {
+			annotations.Parameters = append(annotations.Parameters, parameter)
+		}
+	case "@matchLabels":
+		annotations.MatchLabels = append(annotations.MatchLabels, value)
+	case "@excludedNamespaces":
+		annotations.ExcludedNamespaces = append(annotations.ExcludedNamespaces, value)
+	case "@see":
+		annotations.See = append(annotations.See, value)
+	case "@example":
+		annotations.Examples = append(annotations.Examples, value)
+	}
+}
+
+// appendContinuation folds a non-tag line into the value of the tag it
+// follows, so multi-line tags like @description can span several comments.
+// Continuation lines after @parameters, @matchLabels, @excludedNamespaces and
+// @see are dropped.
+func appendContinuation(annotations *PolicyAnnotations, tag, line string) {
+	if line == "" {
+		return
+	}
+
+	switch tag {
+	case "@title":
+		annotations.Title = strings.TrimSpace(annotations.Title + " " + line)
+	case "@description":
+		annotations.Description = strings.TrimSpace(annotations.Description + "\n" + line)
+	case "@severity":
+		annotations.Severity = strings.TrimSpace(annotations.Severity + " " + line)
+	case "@enforcement":
+		annotations.Enforcement = strings.TrimSpace(annotations.Enforcement + " " + line)
+	case "@example":
+		if n := len(annotations.Examples); n > 0 {
+			annotations.Examples[n-1] = strings.TrimSpace(annotations.Examples[n-1] + "\n" + line)
+		}
+	}
+}
+
+// parseParameter parses a single `@parameters name:type=default` entry. The
+// type and default are both optional.
+func parseParameter(value string) (PolicyParameter, bool) {
+	if value == "" {
+		return PolicyParameter{}, false
+	}
+
+	name := value
+	var typ, def string
+
+	if idx := strings.Index(name, "="); idx >= 0 {
+		def = name[idx+1:]
+		name = name[:idx]
+	}
+	if idx := strings.Index(name, ":"); idx >= 0 {
+		typ = name[idx+1:]
+		name = name[:idx]
+	}
+
+	return PolicyParameter{
+		Name:    strings.TrimSpace(name),
+		Type:    strings.TrimSpace(typ),
+		Default: strings.TrimSpace(def),
+	}, true
+}
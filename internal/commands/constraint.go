@@ -0,0 +1,307 @@
+package commands
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+	"sigs.k8s.io/yaml"
+)
+
+const (
+	constraintTemplateAPIVersion = "templates.gatekeeper.sh/v1beta1"
+	constraintTemplateKind       = "ConstraintTemplate"
+	constraintAPIVersion         = "constraints.gatekeeper.sh/v1beta1"
+)
+
+// NewConstraintCommand creates a new constraint command
+func NewConstraintCommand() *cobra.Command {
+	cmd := cobra.Command{
+		Use:   "constraint <dir>",
+		Short: "Generate Gatekeeper ConstraintTemplate and Constraint manifests from Rego policies",
+		Args:  cobra.ExactArgs(1),
+
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if err := viper.BindPFlag("constraint-output", cmd.Flags().Lookup("output")); err != nil {
+				return fmt.Errorf("bind output flag: %w", err)
+			}
+
+			return runConstraintCommand(args[0])
+		},
+	}
+
+	cmd.Flags().StringP("output", "o", "constraints", "output directory for the generated constraint manifests")
+
+	return &cmd
+}
+
+func runConstraintCommand(path string) error {
+	policies, err := getPolicies(path)
+	if err != nil {
+		return fmt.Errorf("get policies: %w", err)
+	}
+
+	outputDir := filepath.Join(path, viper.GetString("constraint-output"))
+	if err := os.MkdirAll(outputDir, os.ModePerm); err != nil {
+		return fmt.Errorf("create output directory: %w", err)
+	}
+
+	return writeConstraintManifests(policies, outputDir)
+}
+
+// writeConstraintManifests renders a ConstraintTemplate and matching
+// Constraint instance per policy into outputDir.
+func writeConstraintManifests(policies []PolicyCommentBlock, outputDir string) error {
+	sources := packageSources(policies)
+	seen := map[string]int{}
+
+	for _, policy := range policies {
+		base := constraintBase(policy)
+		seen[base]++
+		name, kind := constraintIdentity(base, seen[base])
+
+		templateYAML, err := yaml.Marshal(buildConstraintTemplate(policy, name, kind, sources))
+		if err != nil {
+			return fmt.Errorf("marshal constraint template: %w", err)
+		}
+
+		if err := ioutil.WriteFile(filepath.Join(outputDir, name+"-template.yaml"), templateYAML, os.ModePerm); err != nil {
+			return fmt.Errorf("writing constraint template: %w", err)
+		}
+
+		constraintYAML, err := yaml.Marshal(buildConstraint(policy, name, kind))
+		if err != nil {
+			return fmt.Errorf("marshal constraint: %w", err)
+		}
+
+		if err := ioutil.WriteFile(filepath.Join(outputDir, name+"-constraint.yaml"), constraintYAML, os.ModePerm); err != nil {
+			return fmt.Errorf("writing constraint: %w", err)
+		}
+	}
+
+	return nil
+}
+
+// packageSources maps each policy package path to the Rego source of one of
+// its files, so a policy's imported libs can be embedded alongside it in its
+// generated ConstraintTemplate. Only packages that contain at least one
+// @Kinds policy are included, and only the first such file of each.
+func packageSources(policies []PolicyCommentBlock) map[string]string {
+	sources := map[string]string{}
+	for _, policy := range policies {
+		if _, ok := sources[policy.PackagePath]; !ok {
+			sources[policy.PackagePath] = policy.Source
+		}
+	}
+
+	return sources
+}
+
+// constraintIdentity derives the canonical name/kind pair for a policy's
+// generated constraint manifests. Gatekeeper requires metadata.name to equal
+// lower(names.kind), so both are derived from the same lowercase,
+// alphanumeric-only base rather than independently - a human-readable slug
+// (e.g. "pod-security") would diverge from its title-cased kind
+// ("Podsecurity") the moment the base contains word-boundary punctuation.
+//
+// occurrence is the 1-based count of policies seen so far that share base
+// (as tracked by the caller); when it's greater than 1, it's appended to
+// disambiguate policies that would otherwise collide on the same Kind (or
+// both fall back to the "policy" default), so their manifests don't
+// overwrite each other on disk or collide as Kubernetes resources.
+func constraintIdentity(base string, occurrence int) (name, kind string) {
+	if occurrence > 1 {
+		base = fmt.Sprintf("%s%d", base, occurrence)
+	}
+
+	return base, strings.Title(base)
+}
+
+func constraintBase(policy PolicyCommentBlock) string {
+	if policy.Annotations.Title != "" {
+		return alphanumeric(policy.Annotations.Title)
+	}
+	if len(policy.Kinds) > 0 {
+		return alphanumeric(policy.Kinds[0])
+	}
+
+	return "policy"
+}
+
+// alphanumeric lowercases value and strips everything but letters and
+// digits, so the result is safe to use as both a Kubernetes resource name
+// and (title-cased) a CRD kind.
+func alphanumeric(value string) string {
+	var result strings.Builder
+	for _, r := range strings.ToLower(value) {
+		if (r >= 'a' && r <= 'z') || (r >= '0' && r <= '9') {
+			result.WriteRune(r)
+		}
+	}
+
+	return result.String()
+}
+
+type constraintTemplate struct {
+	APIVersion string                 `json:"apiVersion"`
+	Kind       string                 `json:"kind"`
+	Metadata   map[string]string      `json:"metadata"`
+	Spec       constraintTemplateSpec `json:"spec"`
+}
+
+type constraintTemplateSpec struct {
+	CRD     constraintTemplateCRD `json:"crd"`
+	Targets []constraintTarget    `json:"targets"`
+}
+
+type constraintTemplateCRD struct {
+	Spec constraintTemplateCRDSpec `json:"spec"`
+}
+
+type constraintTemplateCRDSpec struct {
+	Names      constraintTemplateCRDNames       `json:"names"`
+	Validation *constraintTemplateCRDValidation `json:"validation,omitempty"`
+}
+
+type constraintTemplateCRDNames struct {
+	Kind string `json:"kind"`
+}
+
+type constraintTemplateCRDValidation struct {
+	OpenAPIV3Schema map[string]interface{} `json:"openAPIV3Schema"`
+}
+
+type constraintTarget struct {
+	Target string   `json:"target"`
+	Rego   string   `json:"rego"`
+	Libs   []string `json:"libs,omitempty"`
+}
+
+func buildConstraintTemplate(policy PolicyCommentBlock, name, kind string, sources map[string]string) constraintTemplate {
+	var validation *constraintTemplateCRDValidation
+	if len(policy.Annotations.Parameters) > 0 {
+		validation = &constraintTemplateCRDValidation{OpenAPIV3Schema: parametersSchema(policy.Annotations.Parameters)}
+	}
+
+	return constraintTemplate{
+		APIVersion: constraintTemplateAPIVersion,
+		Kind:       constraintTemplateKind,
+		Metadata:   map[string]string{"name": name},
+		Spec: constraintTemplateSpec{
+			CRD: constraintTemplateCRD{
+				Spec: constraintTemplateCRDSpec{
+					Names:      constraintTemplateCRDNames{Kind: kind},
+					Validation: validation,
+				},
+			},
+			Targets: []constraintTarget{
+				{
+					Target: "admission.k8s.gatekeeper.sh",
+					Rego:   policy.Source,
+					Libs:   policyLibs(policy, sources),
+				},
+			},
+		},
+	}
+}
+
+// policyLibs resolves a policy's imports to the Rego source of the packages
+// they reference, as found in sources. Imports of packages that are not in
+// sources (such as a lib package with no @Kinds policy of its own) are
+// skipped, so the generated ConstraintTemplate then does not embed them.
+func policyLibs(policy PolicyCommentBlock, sources map[string]string) []string {
+	var libs []string
+	for _, imp := range policy.Imports {
+		if source, ok := sources[imp]; ok {
+			libs = append(libs, source)
+		}
+	}
+
+	return libs
+}
+
+func parametersSchema(parameters []PolicyParameter) map[string]interface{} {
+	properties := map[string]interface{}{}
+	for _, parameter := range parameters {
+		properties[parameter.Name] = map[string]interface{}{"type": openAPIType(parameter.Type)}
+	}
+
+	return map[string]interface{}{"properties": properties}
+}
+
+func openAPIType(parameterType string) string {
+	switch parameterType {
+	case "int", "integer":
+		return "integer"
+	case "bool", "boolean":
+		return "boolean"
+	case "[]string", "array":
+		return "array"
+	default:
+		return "string"
+	}
+}
+
+type constraint struct {
+	APIVersion string            `json:"apiVersion"`
+	Kind       string            `json:"kind"`
+	Metadata   map[string]string `json:"metadata"`
+	Spec       constraintSpec    `json:"spec"`
+}
+
+type constraintSpec struct {
+	Match constraintMatch `json:"match"`
+}
+
+type constraintMatch struct {
+	Kinds              []constraintMatchKind    `json:"kinds"`
+	LabelSelector      *constraintLabelSelector `json:"labelSelector,omitempty"`
+	ExcludedNamespaces []string                 `json:"excludedNamespaces,omitempty"`
+}
+
+type constraintMatchKind struct {
+	APIGroups []string `json:"apiGroups"`
+	Kinds     []string `json:"kinds"`
+}
+
+type constraintLabelSelector struct {
+	MatchLabels map[string]string `json:"matchLabels,omitempty"`
+}
+
+func buildConstraint(policy PolicyCommentBlock, name, kind string) constraint {
+	match := constraintMatch{
+		Kinds: []constraintMatchKind{
+			{APIGroups: policy.APIGroups, Kinds: policy.Kinds},
+		},
+		ExcludedNamespaces: policy.Annotations.ExcludedNamespaces,
+	}
+
+	if len(policy.Annotations.MatchLabels) > 0 {
+		match.LabelSelector = &constraintLabelSelector{MatchLabels: matchLabelsMap(policy.Annotations.MatchLabels)}
+	}
+
+	return constraint{
+		APIVersion: constraintAPIVersion,
+		Kind:       kind,
+		Metadata:   map[string]string{"name": name},
+		Spec:       constraintSpec{Match: match},
+	}
+}
+
+func matchLabelsMap(matchLabels []string) map[string]string {
+	labels := map[string]string{}
+	for _, label := range matchLabels {
+		parts := strings.SplitN(label, "=", 2)
+		if len(parts) != 2 {
+			continue
+		}
+
+		labels[strings.TrimSpace(parts[0])] = strings.TrimSpace(parts[1])
+	}
+
+	return labels
+}
@@ -6,11 +6,14 @@ import (
 	"io/ioutil"
 	"os"
 	"path/filepath"
+	"sort"
 	"strings"
 
 	"github.com/open-policy-agent/opa/ast"
 	"github.com/spf13/cobra"
 	"github.com/spf13/viper"
+
+	policyloader "github.com/KeisukeYamashita/konstraint/internal/policy"
 )
 
 // NewDocCommand creates a new doc command
@@ -21,15 +24,25 @@ func NewDocCommand() *cobra.Command {
 		Args:  cobra.ExactArgs(1),
 
 		RunE: func(cmd *cobra.Command, args []string) error {
-			if err := viper.BindPFlag("output", cmd.Flags().Lookup("output")); err != nil {
-				return fmt.Errorf("bind output flag: %w", err)
+			for _, flag := range []string{"output", "format", "template", "per-policy", "constraints", "constraints-output", "include", "exclude", "schema"} {
+				if err := viper.BindPFlag(flag, cmd.Flags().Lookup(flag)); err != nil {
+					return fmt.Errorf("bind %s flag: %w", flag, err)
+				}
 			}
 
 			return runDocCommand(args[0])
 		},
 	}
 
-	cmd.Flags().StringP("output", "o", "policies.md", "output directory for the policy documentation")
+	cmd.Flags().StringP("output", "o", "policies.md", "output file for the policy documentation, or output directory when --per-policy is set")
+	cmd.Flags().String("format", formatMarkdown, "documentation output format: markdown, asciidoc, json, html, hugo")
+	cmd.Flags().String("template", "", "path to a custom text/template file, overrides --format")
+	cmd.Flags().Bool("per-policy", false, "write one documentation file per policy instead of a single aggregate file")
+	cmd.Flags().Bool("constraints", false, "also generate Gatekeeper ConstraintTemplate and Constraint manifests")
+	cmd.Flags().String("constraints-output", "constraints", "output directory for generated constraint manifests, relative to <dir>")
+	cmd.Flags().StringSlice("include", []string{"**/*.rego"}, "glob patterns of rego files to include")
+	cmd.Flags().StringSlice("exclude", []string{}, "glob patterns of rego files to exclude")
+	cmd.Flags().String("schema", "", "path to a JSON array of known Kubernetes Kinds to validate @Kinds against")
 
 	return &cmd
 }
@@ -39,95 +52,315 @@ type PolicyCommentBlock struct {
 	APIGroups   []string
 	Kinds       []string
 	Description string
+	Annotations PolicyAnnotations
+	PackagePath string
+	Examples    []PolicyExample
+
+	// Source is the raw Rego source of the file the comment block came
+	// from, and Imports is the set of "data.*" package paths it imports.
+	// Both are used to embed a policy (and the shared libs it depends on)
+	// into its generated Gatekeeper ConstraintTemplate.
+	Source  string
+	Imports []string
 }
 
 func runDocCommand(path string) error {
-	policyDocumentation, err := getPolicyDocumentation(path)
+	renderer, err := newRenderer(viper.GetString("format"), viper.GetString("template"))
+	if err != nil {
+		return fmt.Errorf("resolve renderer: %w", err)
+	}
+
+	policies, err := getPolicies(path)
+	if err != nil {
+		return fmt.Errorf("get policies: %w", err)
+	}
+
+	diagnostics, err := compilePolicies(path)
+	if err != nil {
+		return fmt.Errorf("compile policies: %w", err)
+	}
+
+	schema, err := loadSchemaBundle(viper.GetString("schema"))
+	if err != nil {
+		return fmt.Errorf("load schema: %w", err)
+	}
+	diagnostics = append(diagnostics, verifyKinds(policies, schema)...)
+
+	for _, diagnostic := range diagnostics {
+		fmt.Fprintln(os.Stderr, "warning:", diagnostic)
+	}
+
+	if viper.GetBool("constraints") {
+		outputDir := filepath.Join(path, viper.GetString("constraints-output"))
+		if err := os.MkdirAll(outputDir, os.ModePerm); err != nil {
+			return fmt.Errorf("create constraints output directory: %w", err)
+		}
+
+		if err := writeConstraintManifests(policies, outputDir); err != nil {
+			return fmt.Errorf("write constraint manifests: %w", err)
+		}
+	}
+
+	if viper.GetBool("per-policy") {
+		return writePerPolicyDocumentation(path, renderer, policies)
+	}
+
+	policyDocumentation, err := renderer.Render(policies)
 	if err != nil {
-		return fmt.Errorf("get policy documentation: %w", err)
+		return fmt.Errorf("render policy documentation: %w", err)
 	}
 
 	err = ioutil.WriteFile(filepath.Join(path, viper.GetString("output")), []byte(policyDocumentation), os.ModePerm)
 	if err != nil {
-		return fmt.Errorf("writing constraint: %w", err)
+		return fmt.Errorf("writing policy documentation: %w", err)
 	}
 
 	return nil
 }
 
-func getPolicyDocumentation(path string) (string, error) {
-	regoFilePaths, err := getRegoFilePaths(path)
-	if err != nil {
-		return "", fmt.Errorf("get rego files: %w", err)
+// writePerPolicyDocumentation renders one documentation file per policy into
+// the output directory, instead of a single aggregate file.
+func writePerPolicyDocumentation(path string, renderer DocRenderer, policies []PolicyCommentBlock) error {
+	outputDir := filepath.Join(path, viper.GetString("output"))
+	if err := os.MkdirAll(outputDir, os.ModePerm); err != nil {
+		return fmt.Errorf("create output directory: %w", err)
 	}
 
-	var policyCommentBlocks []PolicyCommentBlock
-	for _, regoFilePath := range regoFilePaths {
-		policyBytes, err := ioutil.ReadFile(regoFilePath)
+	seen := map[string]int{}
+	for _, policy := range policies {
+		rendered, err := renderer.Render([]PolicyCommentBlock{policy})
 		if err != nil {
-			return "", fmt.Errorf("reading file: %w", err)
+			return fmt.Errorf("render policy documentation: %w", err)
 		}
 
-		policyCommentBlock, err := getPolicyCommentBlocks(policyBytes)
-		if err != nil {
-			return "", fmt.Errorf("get policy comment block: %w", err)
+		base := policyFileName(policy)
+		seen[base]++
+		name := base
+		if n := seen[base]; n > 1 {
+			name = fmt.Sprintf("%s-%d", base, n)
 		}
 
-		policyCommentBlocks = append(policyCommentBlocks, policyCommentBlock...)
+		if err := ioutil.WriteFile(filepath.Join(outputDir, name+renderer.Ext()), []byte(rendered), os.ModePerm); err != nil {
+			return fmt.Errorf("writing policy documentation: %w", err)
+		}
 	}
 
-	policyDocument := "# Policies\n\n"
-	policyDocument += "|API Groups|Kinds|Description|\n"
-	policyDocument += "|---|---|---|\n"
+	return nil
+}
 
-	for _, policyCommentBlock := range policyCommentBlocks {
-		apiGroups := strings.Join(policyCommentBlock.APIGroups, ", ")
-		kinds := strings.Join(policyCommentBlock.Kinds, ", ")
-		policyDocument += fmt.Sprintf("|%s|%s|%s|\n", apiGroups, kinds, policyCommentBlock.Description)
+// policyFileName derives a per-policy file name from its package path and
+// declared kinds, so that two policies sharing a first Kind (or lacking
+// Kinds altogether) don't collide. writePerPolicyDocumentation further
+// disambiguates any remaining duplicates with a numeric suffix.
+func policyFileName(policy PolicyCommentBlock) string {
+	switch {
+	case policy.PackagePath != "" && len(policy.Kinds) > 0:
+		return sanitizeFileName(policy.PackagePath + "-" + strings.Join(policy.Kinds, "-"))
+	case policy.PackagePath != "":
+		return sanitizeFileName(policy.PackagePath)
+	case len(policy.Kinds) > 0:
+		return sanitizeFileName(strings.Join(policy.Kinds, "-"))
+	default:
+		return "policy"
 	}
+}
 
-	return policyDocument, nil
+func sanitizeFileName(name string) string {
+	replacer := strings.NewReplacer("/", "-", ".", "-", " ", "-")
+	return strings.ToLower(replacer.Replace(name))
+}
+
+func getPolicies(path string) ([]PolicyCommentBlock, error) {
+	loader := policyloader.NewLoader(viper.GetStringSlice("include"), viper.GetStringSlice("exclude"))
+
+	packages, err := loader.Load(path)
+	if err != nil {
+		return nil, fmt.Errorf("load policies: %w", err)
+	}
+
+	for _, unparsable := range loader.Unparsable {
+		fmt.Fprintf(os.Stderr, "warning: %s: %v\n", unparsable.Path, unparsable.Err)
+	}
+
+	var policyCommentBlocks []PolicyCommentBlock
+	for _, pkg := range packages {
+		for _, regoFilePath := range pkg.Files {
+			policyBytes, err := ioutil.ReadFile(regoFilePath)
+			if err != nil {
+				return nil, fmt.Errorf("reading file: %w", err)
+			}
+
+			policyCommentBlock, err := getPolicyCommentBlocks(policyBytes)
+			if err != nil {
+				return nil, fmt.Errorf("get policy comment block: %w", err)
+			}
+
+			for i := range policyCommentBlock {
+				examples, err := findPolicyExamples(regoFilePath, policyCommentBlock[i].PackagePath)
+				if err != nil {
+					return nil, fmt.Errorf("find policy examples: %w", err)
+				}
+
+				policyCommentBlock[i].Examples = examples
+				policyCommentBlock[i].Source = string(policyBytes)
+			}
+
+			policyCommentBlocks = append(policyCommentBlocks, policyCommentBlock...)
+		}
+	}
+
+	for _, conflict := range detectConflictingKinds(policyCommentBlocks) {
+		fmt.Fprintln(os.Stderr, "warning:", conflict)
+	}
+
+	return policyCommentBlocks, nil
+}
+
+// detectConflictingKinds warns when the same Kind is declared by more than
+// one policy package, since two such policies will both evaluate the same
+// admission decision.
+func detectConflictingKinds(policies []PolicyCommentBlock) []string {
+	owners := map[string][]string{}
+	for _, policy := range policies {
+		for _, kind := range policy.Kinds {
+			owners[kind] = append(owners[kind], policy.PackagePath)
+		}
+	}
+
+	var conflicts []string
+	for kind, packagePaths := range owners {
+		if len(getDedupedGroups(packagePaths)) > 1 {
+			conflicts = append(conflicts, fmt.Sprintf("kind %q is declared by multiple packages: %s", kind, strings.Join(packagePaths, ", ")))
+		}
+	}
+
+	sort.Strings(conflicts)
+
+	return conflicts
 }
 
 func getPolicyCommentBlocks(policy []byte) ([]PolicyCommentBlock, error) {
 	byteReader := bytes.NewReader(policy)
-	_, policyComments, errors := ast.NewParser().WithReader(byteReader).Parse()
+	module, policyComments, errors := ast.NewParser().WithReader(byteReader).Parse()
 	if len(errors) > 0 {
 		return nil, fmt.Errorf("parsing rego: %w", errors)
 	}
 
-	var description string
+	packagePath := strings.TrimPrefix(module.Package.Path.String(), "data.")
+	imports := moduleImports(module)
+
 	var policyCommentBlocks []PolicyCommentBlock
-	for _, policyComment := range policyComments {
-		commentText := string(policyComment.Text)
-		if strings.Contains(commentText, "@Kinds") {
-			kindGroups := strings.Split(commentText, " ")
-			kindGroups = kindGroups[2:]
-
-			var apiGroups []string
-			var kinds []string
-			for _, kindGroup := range kindGroups {
-				kindTokens := strings.Split(kindGroup, "/")
-
-				apiGroups = append(apiGroups, kindTokens[0])
-				kinds = append(kinds, kindTokens[1])
-			}
+	for _, lines := range groupConsecutiveComments(policyComments) {
+		if !containsKindsTag(lines) {
+			continue
+		}
+
+		apiGroups, kinds := parseKinds(lines)
+		annotations := parseAnnotations(stripKindsLines(lines))
+
+		policyCommentBlocks = append(policyCommentBlocks, PolicyCommentBlock{
+			APIGroups:   apiGroups,
+			Kinds:       kinds,
+			Description: annotations.Description,
+			Annotations: annotations,
+			PackagePath: packagePath,
+			Imports:     imports,
+		})
+	}
+
+	return policyCommentBlocks, nil
+}
+
+// moduleImports returns the "data.*" package paths a module imports,
+// skipping the "input" import, which doesn't reference another package.
+func moduleImports(module *ast.Module) []string {
+	var imports []string
+	for _, imp := range module.Imports {
+		path := strings.TrimPrefix(imp.Path.String(), "data.")
+		if path == imp.Path.String() {
+			continue
+		}
+
+		imports = append(imports, path)
+	}
+
+	return imports
+}
 
-			dedupedGroups := getDedupedGroups(apiGroups)
+// groupConsecutiveComments walks the comments found in a rego file and groups
+// the ones that sit on consecutive lines into blocks, mirroring how go/ast
+// associates a comment group with the declaration it documents.
+func groupConsecutiveComments(comments []*ast.Comment) [][]string {
+	var blocks [][]string
+	var current []string
+	lastRow := -1
 
-			policyCommentBlock := PolicyCommentBlock{
-				APIGroups:   dedupedGroups,
-				Kinds:       kinds,
-				Description: strings.Trim(description, " "),
+	for _, comment := range comments {
+		row := comment.Location.Row
+		if lastRow != -1 && row != lastRow+1 {
+			blocks = append(blocks, current)
+			current = nil
+		}
+
+		current = append(current, string(comment.Text))
+		lastRow = row
+	}
+
+	if len(current) > 0 {
+		blocks = append(blocks, current)
+	}
+
+	return blocks
+}
+
+func containsKindsTag(lines []string) bool {
+	for _, line := range lines {
+		if strings.Contains(line, "@Kinds") {
+			return true
+		}
+	}
+
+	return false
+}
+
+// stripKindsLines removes the @Kinds line(s) from a comment block so it
+// doesn't leak into the description or get folded into a preceding tag by
+// parseAnnotations, which doesn't know about @Kinds.
+func stripKindsLines(lines []string) []string {
+	var filtered []string
+	for _, line := range lines {
+		if strings.Contains(line, "@Kinds") {
+			continue
+		}
+
+		filtered = append(filtered, line)
+	}
+
+	return filtered
+}
+
+func parseKinds(lines []string) (apiGroups, kinds []string) {
+	for _, line := range lines {
+		if !strings.Contains(line, "@Kinds") {
+			continue
+		}
+
+		kindGroups := strings.Split(line, " ")
+		kindGroups = kindGroups[2:]
+
+		for _, kindGroup := range kindGroups {
+			kindTokens := strings.Split(kindGroup, "/")
+			if len(kindTokens) != 2 {
+				fmt.Fprintf(os.Stderr, "warning: malformed @Kinds entry %q, expected <group>/<kind>\n", kindGroup)
+				continue
 			}
 
-			policyCommentBlocks = append(policyCommentBlocks, policyCommentBlock)
-		} else {
-			description = commentText
+			apiGroups = append(apiGroups, kindTokens[0])
+			kinds = append(kinds, kindTokens[1])
 		}
 	}
 
-	return policyCommentBlocks, nil
+	return getDedupedGroups(apiGroups), kinds
 }
 
 func getDedupedGroups(groups []string) []string {
@@ -0,0 +1,122 @@
+package commands
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	"github.com/open-policy-agent/opa/rego"
+	"sigs.k8s.io/yaml"
+)
+
+// PolicyExample is a worked example associated with a policy, sourced from an
+// input.yaml/input.json fixture sitting next to the policy and evaluated
+// against its deny/violation rule. Denied reports whether the fixture
+// triggered at least one violation message, i.e. whether admission would be
+// rejected for this input - not whether the evaluation itself succeeded.
+type PolicyExample struct {
+	Input      string
+	Violations []string
+	Denied     bool
+}
+
+// findPolicyExamples looks for an input fixture next to regoFilePath and, if
+// one exists, evaluates data.<packagePath>.violation (falling back to
+// data.<packagePath>.deny) against it, along with any sibling *_test.rego
+// files. It returns nil if no fixture is present.
+func findPolicyExamples(regoFilePath, packagePath string) ([]PolicyExample, error) {
+	dir := filepath.Dir(regoFilePath)
+
+	inputPath, err := findInputFixture(dir)
+	if err != nil {
+		return nil, fmt.Errorf("find input fixture: %w", err)
+	}
+	if inputPath == "" {
+		return nil, nil
+	}
+
+	input, err := readFixtureInput(inputPath)
+	if err != nil {
+		return nil, fmt.Errorf("read input fixture: %w", err)
+	}
+
+	testRegoPaths, err := filepath.Glob(filepath.Join(dir, "*_test.rego"))
+	if err != nil {
+		return nil, fmt.Errorf("glob test files: %w", err)
+	}
+
+	modulePaths := append([]string{regoFilePath}, testRegoPaths...)
+
+	example := PolicyExample{Input: inputPath}
+	for _, rule := range []string{"violation", "deny"} {
+		violations, found, err := evaluateRule(modulePaths, packagePath, rule, input)
+		if err != nil {
+			return nil, fmt.Errorf("evaluate %s: %w", rule, err)
+		}
+		if found {
+			example.Violations = violations
+			example.Denied = true
+			break
+		}
+	}
+
+	return []PolicyExample{example}, nil
+}
+
+func evaluateRule(modulePaths []string, packagePath, rule string, input map[string]interface{}) ([]string, bool, error) {
+	r := rego.New(
+		rego.Query(fmt.Sprintf("data.%s.%s", packagePath, rule)),
+		rego.Load(modulePaths, nil),
+		rego.Input(input),
+	)
+
+	resultSet, err := r.Eval(context.Background())
+	if err != nil {
+		return nil, false, err
+	}
+
+	if len(resultSet) == 0 || len(resultSet[0].Expressions) == 0 {
+		return nil, false, nil
+	}
+
+	messages, ok := resultSet[0].Expressions[0].Value.([]interface{})
+	if !ok || len(messages) == 0 {
+		return nil, false, nil
+	}
+
+	violations := make([]string, 0, len(messages))
+	for _, message := range messages {
+		violations = append(violations, fmt.Sprintf("%v", message))
+	}
+
+	return violations, true, nil
+}
+
+func findInputFixture(dir string) (string, error) {
+	for _, name := range []string{"input.yaml", "input.yml", "input.json"} {
+		candidate := filepath.Join(dir, name)
+		if _, err := os.Stat(candidate); err == nil {
+			return candidate, nil
+		} else if !os.IsNotExist(err) {
+			return "", err
+		}
+	}
+
+	return "", nil
+}
+
+func readFixtureInput(path string) (map[string]interface{}, error) {
+	raw, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var input map[string]interface{}
+	if err := yaml.Unmarshal(raw, &input); err != nil {
+		return nil, fmt.Errorf("unmarshal fixture: %w", err)
+	}
+
+	return input, nil
+}
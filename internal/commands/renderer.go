@@ -0,0 +1,248 @@
+package commands
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"html"
+	"path/filepath"
+	"strings"
+	"text/template"
+)
+
+const (
+	formatMarkdown = "markdown"
+	formatAsciiDoc = "asciidoc"
+	formatJSON     = "json"
+	formatHTML     = "html"
+	formatHugo     = "hugo"
+)
+
+// DocRenderer renders a set of policy comment blocks into documentation
+// output. Ext reports the file extension to use when policies are rendered
+// one file per policy.
+type DocRenderer interface {
+	Render(policies []PolicyCommentBlock) (string, error)
+	Ext() string
+}
+
+// newRenderer resolves the DocRenderer for the given --format, falling back
+// to a custom text/template file when templatePath is set.
+func newRenderer(format, templatePath string) (DocRenderer, error) {
+	if templatePath != "" {
+		return newTemplateRenderer(templatePath)
+	}
+
+	switch format {
+	case formatMarkdown, "":
+		return markdownRenderer{}, nil
+	case formatAsciiDoc:
+		return asciiDocRenderer{}, nil
+	case formatJSON:
+		return jsonRenderer{}, nil
+	case formatHTML:
+		return htmlRenderer{}, nil
+	case formatHugo:
+		return hugoRenderer{}, nil
+	default:
+		return nil, fmt.Errorf("unknown format: %s", format)
+	}
+}
+
+type markdownRenderer struct{}
+
+func (markdownRenderer) Ext() string { return ".md" }
+
+func (markdownRenderer) Render(policies []PolicyCommentBlock) (string, error) {
+	var doc strings.Builder
+	doc.WriteString("# Policies\n\n")
+
+	for _, group := range groupByPackage(policies) {
+		if group.path != "" {
+			fmt.Fprintf(&doc, "## %s\n\n", group.path)
+		}
+
+		doc.WriteString("|API Groups|Kinds|Description|\n")
+		doc.WriteString("|---|---|---|\n")
+
+		for _, policy := range group.policies {
+			fmt.Fprintf(&doc, "|%s|%s|%s|\n",
+				strings.Join(policy.APIGroups, ", "),
+				strings.Join(policy.Kinds, ", "),
+				tableCell(policy.Description))
+		}
+
+		doc.WriteString("\n")
+
+		for _, policy := range group.policies {
+			renderMarkdownExamples(&doc, policy)
+		}
+	}
+
+	return doc.String(), nil
+}
+
+// renderMarkdownExamples writes a "Examples" subsection for each worked
+// example attached to policy, showing the input fixture, whether it was
+// denied, and the violation messages it produced.
+func renderMarkdownExamples(doc *strings.Builder, policy PolicyCommentBlock) {
+	if len(policy.Examples) == 0 {
+		return
+	}
+
+	doc.WriteString("### Examples\n\n")
+
+	for _, example := range policy.Examples {
+		status := "allowed"
+		if example.Denied {
+			status = "denied"
+		}
+
+		fmt.Fprintf(doc, "- `%s` (%s)\n", example.Input, status)
+		for _, violation := range example.Violations {
+			fmt.Fprintf(doc, "  - %s\n", violation)
+		}
+	}
+
+	doc.WriteString("\n")
+}
+
+// policyGroup is a set of policies that share a package path, rendered as
+// their own section.
+type policyGroup struct {
+	path     string
+	policies []PolicyCommentBlock
+}
+
+// tableCell flattens a value for safe use inside a single Markdown/AsciiDoc
+// table cell: embedded newlines (e.g. from a multi-line @description) are
+// collapsed to spaces, and "|" is escaped so it can't be read as a cell
+// boundary.
+func tableCell(value string) string {
+	value = strings.ReplaceAll(value, "\r\n", " ")
+	value = strings.ReplaceAll(value, "\n", " ")
+	value = strings.ReplaceAll(value, "|", "\\|")
+
+	return strings.TrimSpace(value)
+}
+
+// groupByPackage groups policies by PackagePath, preserving the order in
+// which each package was first seen.
+func groupByPackage(policies []PolicyCommentBlock) []policyGroup {
+	var groups []policyGroup
+	index := map[string]int{}
+
+	for _, policy := range policies {
+		i, ok := index[policy.PackagePath]
+		if !ok {
+			i = len(groups)
+			index[policy.PackagePath] = i
+			groups = append(groups, policyGroup{path: policy.PackagePath})
+		}
+
+		groups[i].policies = append(groups[i].policies, policy)
+	}
+
+	return groups
+}
+
+type asciiDocRenderer struct{}
+
+func (asciiDocRenderer) Ext() string { return ".adoc" }
+
+func (asciiDocRenderer) Render(policies []PolicyCommentBlock) (string, error) {
+	var doc strings.Builder
+	doc.WriteString("= Policies\n\n")
+	doc.WriteString("[cols=\"1,1,2\"]\n|===\n|API Groups |Kinds |Description\n\n")
+
+	for _, policy := range policies {
+		fmt.Fprintf(&doc, "|%s\n|%s\n|%s\n\n",
+			strings.Join(policy.APIGroups, ", "),
+			strings.Join(policy.Kinds, ", "),
+			tableCell(policy.Description))
+	}
+
+	doc.WriteString("|===\n")
+
+	return doc.String(), nil
+}
+
+type htmlRenderer struct{}
+
+func (htmlRenderer) Ext() string { return ".html" }
+
+func (htmlRenderer) Render(policies []PolicyCommentBlock) (string, error) {
+	var doc strings.Builder
+	doc.WriteString("<table>\n<tr><th>API Groups</th><th>Kinds</th><th>Description</th></tr>\n")
+
+	for _, policy := range policies {
+		description := strings.ReplaceAll(html.EscapeString(policy.Description), "\n", "<br>")
+		fmt.Fprintf(&doc, "<tr><td>%s</td><td>%s</td><td>%s</td></tr>\n",
+			html.EscapeString(strings.Join(policy.APIGroups, ", ")),
+			html.EscapeString(strings.Join(policy.Kinds, ", ")),
+			description)
+	}
+
+	doc.WriteString("</table>\n")
+
+	return doc.String(), nil
+}
+
+type jsonRenderer struct{}
+
+func (jsonRenderer) Ext() string { return ".json" }
+
+func (jsonRenderer) Render(policies []PolicyCommentBlock) (string, error) {
+	out, err := json.MarshalIndent(policies, "", "  ")
+	if err != nil {
+		return "", fmt.Errorf("marshal policies: %w", err)
+	}
+
+	return string(out), nil
+}
+
+// hugoRenderer wraps the markdown table with Hugo-compatible YAML
+// front-matter so the generated docs can be dropped straight into a Hugo
+// content directory.
+type hugoRenderer struct{}
+
+func (hugoRenderer) Ext() string { return ".md" }
+
+func (r hugoRenderer) Render(policies []PolicyCommentBlock) (string, error) {
+	body, err := (markdownRenderer{}).Render(policies)
+	if err != nil {
+		return "", err
+	}
+
+	frontMatter := "---\ntitle: Policies\n---\n\n"
+
+	return frontMatter + body, nil
+}
+
+// templateRenderer renders policies through a user-provided text/template
+// file, given via --template.
+type templateRenderer struct {
+	tmpl *template.Template
+}
+
+func newTemplateRenderer(path string) (templateRenderer, error) {
+	tmpl, err := template.ParseFiles(path)
+	if err != nil {
+		return templateRenderer{}, fmt.Errorf("parse template: %w", err)
+	}
+
+	return templateRenderer{tmpl: tmpl}, nil
+}
+
+func (r templateRenderer) Ext() string {
+	return filepath.Ext(r.tmpl.Name())
+}
+
+func (r templateRenderer) Render(policies []PolicyCommentBlock) (string, error) {
+	var buf bytes.Buffer
+	if err := r.tmpl.Execute(&buf, policies); err != nil {
+		return "", fmt.Errorf("execute template: %w", err)
+	}
+
+	return buf.String(), nil
+}
@@ -0,0 +1,218 @@
+package commands
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"sort"
+	"strings"
+
+	"github.com/open-policy-agent/opa/ast"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+
+	policyloader "github.com/KeisukeYamashita/konstraint/internal/policy"
+)
+
+// NewVerifyCommand creates a new verify command
+func NewVerifyCommand() *cobra.Command {
+	cmd := cobra.Command{
+		Use:   "verify <dir>",
+		Short: "Validate Rego policies and their Kubernetes metadata",
+		Args:  cobra.ExactArgs(1),
+
+		RunE: func(cmd *cobra.Command, args []string) error {
+			for _, flag := range []string{"schema", "include", "exclude"} {
+				if err := viper.BindPFlag(flag, cmd.Flags().Lookup(flag)); err != nil {
+					return fmt.Errorf("bind %s flag: %w", flag, err)
+				}
+			}
+
+			return runVerifyCommand(args[0])
+		},
+	}
+
+	cmd.Flags().String("schema", "", "path to a JSON array of known Kubernetes Kinds to validate @Kinds against")
+	cmd.Flags().StringSlice("include", []string{"**/*.rego"}, "glob patterns of rego files to include")
+	cmd.Flags().StringSlice("exclude", []string{}, "glob patterns of rego files to exclude")
+
+	return &cmd
+}
+
+func runVerifyCommand(path string) error {
+	diagnostics, err := compilePolicies(path)
+	if err != nil {
+		return fmt.Errorf("compile policies: %w", err)
+	}
+
+	policies, err := getPolicies(path)
+	if err != nil {
+		return fmt.Errorf("get policies: %w", err)
+	}
+
+	schema, err := loadSchemaBundle(viper.GetString("schema"))
+	if err != nil {
+		return fmt.Errorf("load schema: %w", err)
+	}
+
+	diagnostics = append(diagnostics, verifyKinds(policies, schema)...)
+	sort.Strings(diagnostics)
+
+	for _, diagnostic := range diagnostics {
+		fmt.Println(diagnostic)
+	}
+
+	if len(diagnostics) > 0 {
+		return fmt.Errorf("%d issue(s) found", len(diagnostics))
+	}
+
+	return nil
+}
+
+// compilePolicies discovers every rego file under path and compiles them all
+// together with ast.Compiler, so that references across policy packages
+// (e.g. a shared lib package) resolve correctly. It reports the compiler's
+// errors (such as unsafe variables) as file:line diagnostics, and missing
+// deny/violation rules or @Kinds annotations per file, rather than failing
+// outright. No separate check is made for unreferenced rules or for imports
+// of packages that do not exist.
+func compilePolicies(path string) ([]string, error) {
+	loader := policyloader.NewLoader(viper.GetStringSlice("include"), viper.GetStringSlice("exclude"))
+
+	packages, err := loader.Load(path)
+	if err != nil {
+		return nil, fmt.Errorf("load policies: %w", err)
+	}
+
+	var diagnostics []string
+	for _, unparsable := range loader.Unparsable {
+		diagnostics = append(diagnostics, fmt.Sprintf("%s: %v", unparsable.Path, unparsable.Err))
+	}
+
+	modules := map[string]*ast.Module{}
+	for _, pkg := range packages {
+		for _, filePath := range pkg.Files {
+			contents, err := ioutil.ReadFile(filePath)
+			if err != nil {
+				return nil, fmt.Errorf("reading %s: %w", filePath, err)
+			}
+
+			module, comments, errs := ast.NewParser().WithFilename(filePath).WithReader(bytes.NewReader(contents)).Parse()
+			if len(errs) > 0 {
+				return nil, fmt.Errorf("parsing %s: %w", filePath, errs)
+			}
+
+			modules[filePath] = module
+			diagnostics = append(diagnostics, verifyPolicyModule(filePath, module, comments)...)
+		}
+	}
+
+	compiler := ast.NewCompiler()
+	compiler.Compile(modules)
+	if compiler.Failed() {
+		for _, compileErr := range compiler.Errors {
+			diagnostics = append(diagnostics, compileErr.Error())
+		}
+	}
+
+	return diagnostics, nil
+}
+
+// verifyPolicyModule checks that a policy module carries both a deny or
+// violation rule and an @Kinds annotation. It's scoped to actual policy
+// modules: *_test.rego files are skipped outright, and a module with
+// neither a rule nor an annotation is treated as a shared lib/helper file
+// rather than a policy, so it's skipped too.
+func verifyPolicyModule(filePath string, module *ast.Module, comments []*ast.Comment) []string {
+	if strings.HasSuffix(filePath, "_test.rego") {
+		return nil
+	}
+
+	hasRule := hasDenyOrViolationRule(module)
+	hasKinds := hasKindsAnnotation(comments)
+
+	var diagnostics []string
+	switch {
+	case !hasRule && !hasKinds:
+		// Neither a rule nor an annotation: a shared lib/helper module, not a policy.
+	case !hasRule:
+		diagnostics = append(diagnostics, fmt.Sprintf("%s: no deny or violation rule found", filePath))
+	case !hasKinds:
+		diagnostics = append(diagnostics, fmt.Sprintf("%s: no @Kinds annotation", filePath))
+	}
+
+	return diagnostics
+}
+
+func hasDenyOrViolationRule(module *ast.Module) bool {
+	for _, rule := range module.Rules {
+		name := rule.Head.Name.String()
+		if name == "deny" || name == "violation" {
+			return true
+		}
+	}
+
+	return false
+}
+
+func hasKindsAnnotation(comments []*ast.Comment) bool {
+	for _, lines := range groupConsecutiveComments(comments) {
+		if containsKindsTag(lines) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func verifyKinds(policies []PolicyCommentBlock, schema *schemaBundle) []string {
+	var diagnostics []string
+
+	if schema == nil {
+		return diagnostics
+	}
+
+	for _, policy := range policies {
+		for _, kind := range policy.Kinds {
+			if !schema.has(kind) {
+				diagnostics = append(diagnostics, fmt.Sprintf("%s: kind %q not found in schema bundle", policy.PackagePath, kind))
+			}
+		}
+	}
+
+	return diagnostics
+}
+
+// schemaBundle is the set of Kubernetes Kinds declared valid by a
+// user-provided --schema file.
+type schemaBundle struct {
+	kinds map[string]bool
+}
+
+func (s *schemaBundle) has(kind string) bool {
+	return s.kinds[kind]
+}
+
+func loadSchemaBundle(path string) (*schemaBundle, error) {
+	if path == "" {
+		return nil, nil
+	}
+
+	contents, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var kinds []string
+	if err := json.Unmarshal(contents, &kinds); err != nil {
+		return nil, fmt.Errorf("parse schema bundle: %w", err)
+	}
+
+	kindSet := map[string]bool{}
+	for _, kind := range kinds {
+		kindSet[kind] = true
+	}
+
+	return &schemaBundle{kinds: kindSet}, nil
+}
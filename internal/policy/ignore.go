@@ -0,0 +1,95 @@
+package policy
+
+import (
+	"io/ioutil"
+	"os"
+	"regexp"
+	"strings"
+)
+
+// ignoreList is a set of glob patterns read from a .konstraintignore file,
+// one pattern per line, blank lines and "#" comments ignored.
+type ignoreList struct {
+	patterns []string
+}
+
+func loadIgnoreFile(path string) (*ignoreList, error) {
+	contents, err := ioutil.ReadFile(path)
+	if os.IsNotExist(err) {
+		return &ignoreList{}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var patterns []string
+	for _, line := range strings.Split(string(contents), "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		patterns = append(patterns, line)
+	}
+
+	return &ignoreList{patterns: patterns}, nil
+}
+
+func (i *ignoreList) matches(rel string) bool {
+	for _, pattern := range i.patterns {
+		if matchGlob(pattern, rel) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// matchGlob reports whether path matches pattern, where pattern may use "*"
+// to match within a path segment, "?" for a single character, and "**" to
+// match across path segments (e.g. "**/*.rego").
+func matchGlob(pattern, path string) bool {
+	matched, err := regexp.MatchString(globToRegexp(pattern), filepath2Slash(path))
+	if err != nil {
+		return false
+	}
+
+	return matched
+}
+
+func filepath2Slash(path string) string {
+	return strings.ReplaceAll(path, "\\", "/")
+}
+
+func globToRegexp(pattern string) string {
+	var b strings.Builder
+	b.WriteString("^")
+
+	pattern = filepath2Slash(pattern)
+	for i := 0; i < len(pattern); {
+		switch {
+		case strings.HasPrefix(pattern[i:], "**/"):
+			b.WriteString("(?:.*/)?")
+			i += 3
+		case strings.HasPrefix(pattern[i:], "**"):
+			b.WriteString(".*")
+			i += 2
+		case pattern[i] == '*':
+			b.WriteString("[^/]*")
+			i++
+		case pattern[i] == '?':
+			b.WriteString("[^/]")
+			i++
+		case strings.ContainsRune(`.()+|^$[]{}\`, rune(pattern[i])):
+			b.WriteString("\\" + string(pattern[i]))
+			i++
+		default:
+			b.WriteByte(pattern[i])
+			i++
+		}
+	}
+
+	b.WriteString("$")
+
+	return b.String()
+}
@@ -0,0 +1,155 @@
+// Package policy discovers Rego policy files on disk and groups them by
+// their declared package.
+package policy
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/open-policy-agent/opa/ast"
+)
+
+// Loader discovers rego policy files in a directory tree, honoring include
+// and exclude glob patterns as well as a .konstraintignore file at the root
+// of the walk.
+type Loader struct {
+	Include []string
+	Exclude []string
+
+	// Unparsable is populated by Load with any file that failed to parse, so
+	// that a caller can surface it as a diagnostic instead of the whole load
+	// failing because of a single broken policy.
+	Unparsable []UnparsableFile
+}
+
+// NewLoader creates a Loader with the given include/exclude glob patterns.
+// When include is empty, every ".rego" file under the walked root is
+// considered.
+func NewLoader(include, exclude []string) *Loader {
+	return &Loader{Include: include, Exclude: exclude}
+}
+
+// Package groups the rego files that share a package path.
+type Package struct {
+	Path  string
+	Files []string
+}
+
+// UnparsableFile records a rego file that failed to parse during Load, along
+// with the error encountered.
+type UnparsableFile struct {
+	Path string
+	Err  error
+}
+
+// Load recursively walks root and returns the discovered rego files grouped
+// by their `package` declaration, sorted by package path.
+func (l *Loader) Load(root string) ([]Package, error) {
+	ignore, err := loadIgnoreFile(filepath.Join(root, ".konstraintignore"))
+	if err != nil {
+		return nil, fmt.Errorf("load .konstraintignore: %w", err)
+	}
+
+	var filePaths []string
+	err = filepath.Walk(root, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if info.IsDir() {
+			return nil
+		}
+		if filepath.Ext(path) != ".rego" {
+			return nil
+		}
+
+		rel, err := filepath.Rel(root, path)
+		if err != nil {
+			return err
+		}
+
+		if !l.included(rel) || l.excluded(rel) || ignore.matches(rel) {
+			return nil
+		}
+
+		filePaths = append(filePaths, path)
+
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("walk %s: %w", root, err)
+	}
+
+	return l.groupByPackage(filePaths)
+}
+
+func (l *Loader) included(rel string) bool {
+	if len(l.Include) == 0 {
+		return true
+	}
+
+	for _, pattern := range l.Include {
+		if matchGlob(pattern, rel) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func (l *Loader) excluded(rel string) bool {
+	for _, pattern := range l.Exclude {
+		if matchGlob(pattern, rel) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// groupByPackage parses the `package` declaration of each file and groups
+// files that share the same package path, returning groups sorted by path.
+// Files that fail to parse are recorded on l.Unparsable rather than failing
+// the whole load.
+func (l *Loader) groupByPackage(filePaths []string) ([]Package, error) {
+	l.Unparsable = nil
+
+	groups := map[string]*Package{}
+	var order []string
+
+	for _, path := range filePaths {
+		contents, err := ioutil.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("reading %s: %w", path, err)
+		}
+
+		module, err := ast.ParseModule(path, string(contents))
+		if err != nil {
+			l.Unparsable = append(l.Unparsable, UnparsableFile{Path: path, Err: err})
+			continue
+		}
+
+		packagePath := strings.TrimPrefix(module.Package.Path.String(), "data.")
+
+		group, ok := groups[packagePath]
+		if !ok {
+			group = &Package{Path: packagePath}
+			groups[packagePath] = group
+			order = append(order, packagePath)
+		}
+
+		group.Files = append(group.Files, path)
+	}
+
+	sort.Strings(order)
+
+	packages := make([]Package, 0, len(order))
+	for _, packagePath := range order {
+		packages = append(packages, *groups[packagePath])
+	}
+
+	return packages, nil
+}